@@ -3,9 +3,11 @@ package loader
 import (
 	"bytes"
 	"errors"
-	"io/ioutil"
-	"os"
+	"fmt"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/nuclei/v2/pkg/catalog"
@@ -31,6 +33,38 @@ type Config struct {
 	Catalog            *catalog.Catalog
 	ExecutorOptions    protocols.ExecuterOptions
 	TemplatesDirectory string
+
+	// Threads specifies the number of concurrent workers used to load and
+	// parse templates. If zero or negative, runtime.NumCPU() is used.
+	Threads int
+
+	// RemoteSources are additional git or HTTP(S) locations that templates
+	// are fetched from and merged into the local template set.
+	RemoteSources []RemoteSource
+	// UpdateRemote forces RemoteSources to be re-fetched even if a cached
+	// copy already exists under TemplatesDirectory/.remote.
+	UpdateRemote bool
+
+	// FilterExpression is a boolean DSL evaluated against template metadata,
+	// e.g. `severity in (high, critical) and tag == "cve" and not tag == "dos"`.
+	// When set, it is combined with (not a replacement for) the Tags/Authors/
+	// Severities filters above.
+	FilterExpression string
+}
+
+// loadTask is a unit of work dispatched to the template loading worker pool.
+type loadTask struct {
+	path     string
+	workflow bool
+}
+
+// loadResult is the outcome of loading and parsing a single template or
+// workflow, produced by a worker and consumed by the collector goroutine.
+type loadResult struct {
+	path     string
+	workflow bool
+	template *templates.Template
+	warning  string
 }
 
 // Store is a storage for loaded nuclei templates
@@ -39,8 +73,10 @@ type Store struct {
 	config         *Config
 	finalTemplates []string
 
-	templates []*templates.Template
-	workflows []*templates.Template
+	mu         sync.RWMutex
+	templates  map[string]*templates.Template
+	workflows  map[string]*templates.Template
+	filterExpr filterExpr
 }
 
 // New creates a new template store based on provided configuration
@@ -49,6 +85,15 @@ func New(config *Config) (*Store, error) {
 	store := &Store{
 		config:    config,
 		tagFilter: config.createTagFilter(),
+		templates: make(map[string]*templates.Template),
+		workflows: make(map[string]*templates.Template),
+	}
+	if config.FilterExpression != "" {
+		expr, err := parseFilterExpression(config.FilterExpression)
+		if err != nil {
+			return nil, err
+		}
+		store.filterExpr = expr
 	}
 
 	// Handle a case with no templates or workflows, where we use base directory
@@ -57,21 +102,48 @@ func New(config *Config) (*Store, error) {
 	}
 	store.finalTemplates = append(store.finalTemplates, config.Templates...)
 
+	remotePaths, err := loadRemoteSources(config)
+	if err != nil {
+		return nil, err
+	}
+	store.finalTemplates = append(store.finalTemplates, remotePaths...)
+
 	return store, nil
 }
 
 // Templates returns all the templates in the store
 func (s *Store) Templates() []*templates.Template {
-	return s.templates
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]*templates.Template, 0, len(s.templates))
+	for _, template := range s.templates {
+		values = append(values, template)
+	}
+	return values
 }
 
 // Workflows returns all the workflows in the store
 func (s *Store) Workflows() []*templates.Template {
-	return s.workflows
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]*templates.Template, 0, len(s.workflows))
+	for _, workflow := range s.workflows {
+		values = append(values, workflow)
+	}
+	return values
 }
 
 // Load loads all the templates from a store, performs filtering and returns
 // the complete compiled templates for a nuclei execution configuration.
+//
+// Candidate paths are dispatched to a bounded pool of workers (sized via
+// Config.Threads, defaulting to runtime.NumCPU()) which load, filter and
+// parse templates concurrently. A single collector goroutine stores the
+// results in s.templates/s.workflows. Warnings are printed once loading has
+// finished, sorted by their full message text (so by failure kind first,
+// then by path).
 func (s *Store) Load() {
 	includedTemplates := s.config.Catalog.GetTemplatesPath(s.finalTemplates)
 	includedWorkflows := s.config.Catalog.GetTemplatesPath(s.config.Workflows)
@@ -95,21 +167,6 @@ func (s *Store) Load() {
 		}
 	}
 
-	for k := range templatesMap {
-		loaded, err := s.loadTemplateParseMetadata(k, false)
-		if err != nil {
-			gologger.Warning().Msgf("Could not load template %s: %s\n", k, err)
-		}
-		if loaded {
-			parsed, err := templates.Parse(k, s.config.ExecutorOptions)
-			if err != nil {
-				gologger.Warning().Msgf("Could not parse template %s: %s\n", k, err)
-			} else if parsed != nil {
-				s.templates = append(s.templates, parsed)
-			}
-		}
-	}
-
 	workflowsMap := make(map[string]struct{})
 	for _, tpl := range includedWorkflows {
 		workflowsMap[tpl] = struct{}{}
@@ -121,48 +178,141 @@ func (s *Store) Load() {
 			delete(templatesMap, template)
 		}
 	}
+
+	tasks := make([]loadTask, 0, len(templatesMap)+len(workflowsMap))
+	for k := range templatesMap {
+		tasks = append(tasks, loadTask{path: k, workflow: false})
+	}
 	for k := range workflowsMap {
-		loaded, err := s.loadTemplateParseMetadata(k, true)
-		if err != nil {
-			gologger.Warning().Msgf("Could not load workflow %s: %s\n", k, err)
-		}
+		tasks = append(tasks, loadTask{path: k, workflow: true})
+	}
+	s.loadTasks(tasks)
+}
+
+// loadTasks dispatches tasks to a bounded worker pool, collects the results
+// on a single goroutine and finally flushes any warnings in sorted order.
+func (s *Store) loadTasks(tasks []loadTask) {
+	threads := s.config.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	if threads > len(tasks) {
+		threads = len(tasks)
+	}
+	if threads <= 0 {
+		return
+	}
+
+	taskChan := make(chan loadTask, len(tasks))
+	for _, task := range tasks {
+		taskChan <- task
+	}
+	close(taskChan)
+
+	resultChan := make(chan loadResult, len(tasks))
 
-		if loaded {
-			parsed, err := templates.Parse(k, s.config.ExecutorOptions)
-			if err != nil {
-				gologger.Warning().Msgf("Could not parse workflow %s: %s\n", k, err)
-			} else if parsed != nil {
-				s.workflows = append(s.workflows, parsed)
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskChan {
+				resultChan <- s.runLoadTask(task)
 			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	var warnings []string
+	for result := range resultChan {
+		if result.warning != "" {
+			warnings = append(warnings, result.warning)
+		}
+		if result.template == nil {
+			continue
 		}
+		s.mu.Lock()
+		if result.workflow {
+			s.workflows[result.path] = result.template
+		} else {
+			s.templates[result.path] = result.template
+		}
+		s.mu.Unlock()
+	}
+
+	sort.Strings(warnings)
+	for _, warning := range warnings {
+		gologger.Warning().Msgf("%s\n", warning)
 	}
 }
 
-// loadTemplateParseMetadata loads a template by parsing metadata and running
-// all tag and path based filters on the template.
-func (s *Store) loadTemplateParseMetadata(templatePath string, workflow bool) (bool, error) {
-	f, err := os.Open(templatePath)
+// runLoadTask loads, filters and parses a single template or workflow,
+// returning the outcome for the collector goroutine to merge.
+//
+// The file is read once and decoded into a *templates.Template once, in
+// loadTemplateParseMetadata (after resolveTemplateBytes has decoded it into
+// a generic map to look for extends/includes): the resulting
+// *templates.Template is reused for compilation via
+// templates.ParseFromDecoded instead of re-opening and re-decoding the file.
+func (s *Store) runLoadTask(task loadTask) loadResult {
+	result := loadResult{path: task.path, workflow: task.workflow}
+
+	decoded, loaded, err := s.loadTemplateParseMetadata(task.path, task.workflow)
+	if err != nil {
+		kind := "template"
+		if task.workflow {
+			kind = "workflow"
+		}
+		result.warning = fmt.Sprintf("Could not load %s %s: %s", kind, task.path, err)
+		return result
+	}
+	if !loaded {
+		return result
+	}
+
+	parsed, err := templates.ParseFromDecoded(decoded, task.path, s.config.ExecutorOptions)
 	if err != nil {
-		return false, err
+		kind := "template"
+		if task.workflow {
+			kind = "workflow"
+		}
+		result.warning = fmt.Sprintf("Could not parse %s %s: %s", kind, task.path, err)
+		return result
+	}
+	if parsed != nil {
+		result.template = parsed
 	}
-	defer f.Close()
+	return result
+}
 
-	data, err := ioutil.ReadAll(f)
+// loadTemplateParseMetadata reads and decodes a template file once, returning
+// the decoded *templates.Template alongside whether it survived tag/path/type
+// filtering. Callers that get loaded == true should feed the returned
+// template into templates.ParseFromDecoded rather than re-reading the file.
+//
+// If the template declares `extends`/`includes`, the referenced documents
+// are resolved and YAML-merged first via resolveTemplateBytes so the rest of
+// this function sees a single, already-merged document.
+func (s *Store) loadTemplateParseMetadata(templatePath string, workflow bool) (*templates.Template, bool, error) {
+	data, err := s.resolveTemplateBytes(templatePath)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 
 	template := &templates.Template{}
 	err = yaml.NewDecoder(bytes.NewReader(data)).Decode(template)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 	if _, ok := template.Info["name"]; !ok {
-		return false, errors.New("no template name field provided")
+		return nil, false, errors.New("no template name field provided")
 	}
 	author, ok := template.Info["author"]
 	if !ok {
-		return false, errors.New("no template author field provided")
+		return nil, false, errors.New("no template author field provided")
 	}
 	severity, ok := template.Info["severity"]
 	if !ok {
@@ -185,7 +335,7 @@ func (s *Store) loadTemplateParseMetadata(templatePath string, workflow bool) (b
 		for _, author := range authors {
 			match, err := s.tagFilter.match(strings.TrimSpace(tag), strings.TrimSpace(author), severityStr)
 			if err == ErrExcluded {
-				return false, ErrExcluded
+				return nil, false, ErrExcluded
 			}
 			if !matched && match && err == nil {
 				matched = true
@@ -193,13 +343,22 @@ func (s *Store) loadTemplateParseMetadata(templatePath string, workflow bool) (b
 		}
 	}
 	if !matched {
-		return false, nil
+		return nil, false, nil
+	}
+	if s.filterExpr != nil {
+		matched, err = s.filterExpr.Eval(template.Info)
+		if err != nil {
+			return nil, false, fmt.Errorf("could not evaluate filter expression: %w", err)
+		}
+		if !matched {
+			return nil, false, nil
+		}
 	}
 	if len(template.Workflows) == 0 && workflow {
-		return false, nil
+		return nil, false, nil
 	}
 	if len(template.Workflows) > 0 && !workflow {
-		return false, nil
+		return nil, false, nil
 	}
-	return true, nil
+	return template, true, nil
 }
\ No newline at end of file
@@ -0,0 +1,83 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/nuclei/v2/pkg/templates"
+)
+
+const benchTemplateYAML = `
+id: bench-template
+
+info:
+  name: Bench Template
+  author: pdteam
+  severity: info
+  tags: bench
+
+requests:
+  - method: GET
+    path:
+      - "{{BaseURL}}"
+    matchers:
+      - type: status
+        status:
+          - 200
+`
+
+// newBenchStore writes benchTemplateYAML to dir and returns a Store
+// configured against it, ready for loadTemplateParseMetadata/
+// templates.ParseFromDecoded benchmarking.
+func newBenchStore(b *testing.B, dir string) (*Store, string) {
+	b.Helper()
+
+	path := filepath.Join(dir, "bench-template.yaml")
+	if err := os.WriteFile(path, []byte(benchTemplateYAML), 0644); err != nil {
+		b.Fatalf("could not write benchmark template: %s", err)
+	}
+
+	store, err := New(&Config{TemplatesDirectory: dir})
+	if err != nil {
+		b.Fatalf("could not create store: %s", err)
+	}
+	return store, path
+}
+
+// BenchmarkLoadTemplateParseMetadataThenParse exercises the old two-decode
+// shape: metadata is decoded once by loadTemplateParseMetadata purely to
+// filter, and the returned template is discarded in favor of a second,
+// independent decode done by templates.Parse.
+func BenchmarkLoadTemplateParseMetadataThenParse(b *testing.B) {
+	store, path := newBenchStore(b, b.TempDir())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, loaded, err := store.loadTemplateParseMetadata(path, false); err != nil || !loaded {
+			b.Fatalf("loadTemplateParseMetadata failed: loaded=%v err=%s", loaded, err)
+		}
+		if _, err := templates.Parse(path, store.config.ExecutorOptions); err != nil {
+			b.Fatalf("templates.Parse failed: %s", err)
+		}
+	}
+}
+
+// BenchmarkLoadTemplateParseMetadataFromDecoded exercises the single-decode
+// path this series introduced: the *templates.Template already decoded by
+// loadTemplateParseMetadata flows straight into templates.ParseFromDecoded,
+// with no second read or YAML decode of the file.
+func BenchmarkLoadTemplateParseMetadataFromDecoded(b *testing.B) {
+	store, path := newBenchStore(b, b.TempDir())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoded, loaded, err := store.loadTemplateParseMetadata(path, false)
+		if err != nil || !loaded {
+			b.Fatalf("loadTemplateParseMetadata failed: loaded=%v err=%s", loaded, err)
+		}
+		if _, err := templates.ParseFromDecoded(decoded, path, store.config.ExecutorOptions); err != nil {
+			b.Fatalf("templates.ParseFromDecoded failed: %s", err)
+		}
+	}
+}
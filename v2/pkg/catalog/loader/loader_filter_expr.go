@@ -0,0 +1,428 @@
+package loader
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/nuclei/v2/pkg/types"
+)
+
+// filterExpr is the interface implemented by every node of a parsed
+// Config.FilterExpression. Eval is evaluated against a decoded template's
+// info map (template.Info, keyed by lowercase field name).
+type filterExpr interface {
+	Eval(info map[string]interface{}) (bool, error)
+}
+
+type andExpr struct{ left, right filterExpr }
+
+func (e *andExpr) Eval(info map[string]interface{}) (bool, error) {
+	left, err := e.left.Eval(info)
+	if err != nil || !left {
+		return false, err
+	}
+	return e.right.Eval(info)
+}
+
+type orExpr struct{ left, right filterExpr }
+
+func (e *orExpr) Eval(info map[string]interface{}) (bool, error) {
+	left, err := e.left.Eval(info)
+	if err != nil {
+		return false, err
+	}
+	if left {
+		return true, nil
+	}
+	return e.right.Eval(info)
+}
+
+type notExpr struct{ inner filterExpr }
+
+func (e *notExpr) Eval(info map[string]interface{}) (bool, error) {
+	ok, err := e.inner.Eval(info)
+	if err != nil {
+		return false, err
+	}
+	return !ok, nil
+}
+
+// cmpExpr compares a template metadata field, e.g. `severity`, `tag` or
+// `author`, against a literal using one of ==, !=, in, contains or matches.
+type cmpExpr struct {
+	field string
+	op    string
+	value interface{}
+}
+
+func (e *cmpExpr) Eval(info map[string]interface{}) (bool, error) {
+	values := fieldValues(info, e.field)
+
+	switch e.op {
+	case "==":
+		want, ok := e.value.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: == requires a string literal", e.field)
+		}
+		return containsFold(values, want), nil
+	case "!=":
+		want, ok := e.value.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: != requires a string literal", e.field)
+		}
+		return !containsFold(values, want), nil
+	case "in":
+		list, ok := e.value.([]string)
+		if !ok {
+			return false, fmt.Errorf("%s: in requires a list literal", e.field)
+		}
+		for _, v := range values {
+			if containsFold(list, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "contains":
+		want, ok := e.value.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: contains requires a string literal", e.field)
+		}
+		for _, v := range values {
+			if strings.Contains(strings.ToLower(v), strings.ToLower(want)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "matches":
+		want, ok := e.value.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: matches requires a string glob literal", e.field)
+		}
+		for _, v := range values {
+			if matched, _ := path.Match(want, v); matched {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported operator %q", e.op)
+	}
+}
+
+// fieldValues resolves a DSL field name to the (possibly comma-separated)
+// values it holds in a decoded template's info map.
+func fieldValues(info map[string]interface{}, field string) []string {
+	key := field
+	switch field {
+	case "tag":
+		key = "tags"
+	case "author":
+		key = "author"
+	}
+	raw := types.ToString(info[key])
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterExpressionError reports a syntax error found while parsing a
+// Config.FilterExpression, including the rune offset it occurred at.
+type FilterExpressionError struct {
+	Pos int
+	Msg string
+}
+
+func (e *FilterExpressionError) Error() string {
+	return fmt.Sprintf("filter expression: %s (at position %d)", e.Msg, e.Pos)
+}
+
+// tokenKind enumerates the lexical token kinds recognized by the filter
+// expression lexer.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokLParen
+	tokRParen
+	tokComma
+	tokEq
+	tokNeq
+	tokAnd
+	tokOr
+	tokNot
+	tokIn
+	tokContains
+	tokMatches
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var keywordTokens = map[string]tokenKind{
+	"and":      tokAnd,
+	"or":       tokOr,
+	"not":      tokNot,
+	"in":       tokIn,
+	"contains": tokContains,
+	"matches":  tokMatches,
+}
+
+// lexFilterExpression tokenizes a filter expression, returning a lexical
+// error wrapped as *FilterExpressionError on malformed input.
+func lexFilterExpression(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case r == '=' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, token{tokEq, "==", i})
+			i += 2
+		case r == '!' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, token{tokNeq, "!=", i})
+			i += 2
+		case r == '"' || r == '\'':
+			start := i
+			quote := r
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != quote {
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, &FilterExpressionError{Pos: start, Msg: "unterminated string literal"}
+			}
+			i++ // consume closing quote
+			tokens = append(tokens, token{tokString, sb.String(), start})
+		case r >= '0' && r <= '9':
+			start := i
+			for i < len(runes) && (runes[i] >= '0' && runes[i] <= '9' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case isIdentRune(r):
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			if kind, ok := keywordTokens[strings.ToLower(word)]; ok {
+				tokens = append(tokens, token{kind, word, start})
+			} else {
+				tokens = append(tokens, token{tokIdent, word, start})
+			}
+		default:
+			return nil, &FilterExpressionError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	tokens = append(tokens, token{tokEOF, "", len(runes)})
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// filterExprParser is a small recursive-descent parser implementing:
+//
+//	expr   := orExpr
+//	orExpr := andExpr ("or" andExpr)*
+//	andExpr:= unary ("and" unary)*
+//	unary  := "not" unary | "(" expr ")" | cmp
+//	cmp    := IDENT ("==" | "!=" | "in" | "contains" | "matches") value
+//	value  := STRING | IDENT | NUMBER | "(" value ("," value)* ")"
+type filterExprParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseFilterExpression parses the DSL described in Config.FilterExpression
+// into an evaluable filterExpr tree.
+func parseFilterExpression(input string) (filterExpr, error) {
+	tokens, err := lexFilterExpression(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterExprParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.current().kind != tokEOF {
+		return nil, &FilterExpressionError{Pos: p.current().pos, Msg: fmt.Sprintf("unexpected token %q", p.current().text)}
+	}
+	return expr, nil
+}
+
+func (p *filterExprParser) current() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterExprParser) advance() token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterExprParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.current().kind == tokOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterExprParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.current().kind == tokAnd {
+		p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterExprParser) parseUnary() (filterExpr, error) {
+	switch p.current().kind {
+	case tokNot:
+		p.advance()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{inner: inner}, nil
+	case tokLParen:
+		p.advance()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.current().kind != tokRParen {
+			return nil, &FilterExpressionError{Pos: p.current().pos, Msg: "expected closing ')'"}
+		}
+		p.advance()
+		return expr, nil
+	default:
+		return p.parseCmp()
+	}
+}
+
+func (p *filterExprParser) parseCmp() (filterExpr, error) {
+	fieldTok := p.current()
+	if fieldTok.kind != tokIdent {
+		return nil, &FilterExpressionError{Pos: fieldTok.pos, Msg: fmt.Sprintf("expected field name, found %q", fieldTok.text)}
+	}
+	p.advance()
+
+	opTok := p.current()
+	var op string
+	switch opTok.kind {
+	case tokEq:
+		op = "=="
+	case tokNeq:
+		op = "!="
+	case tokIn:
+		op = "in"
+	case tokContains:
+		op = "contains"
+	case tokMatches:
+		op = "matches"
+	default:
+		return nil, &FilterExpressionError{Pos: opTok.pos, Msg: fmt.Sprintf("expected an operator, found %q", opTok.text)}
+	}
+	p.advance()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &cmpExpr{field: strings.ToLower(fieldTok.text), op: op, value: value}, nil
+}
+
+func (p *filterExprParser) parseValue() (interface{}, error) {
+	tok := p.current()
+	switch tok.kind {
+	case tokString, tokIdent:
+		p.advance()
+		return tok.text, nil
+	case tokNumber:
+		p.advance()
+		if _, err := strconv.ParseFloat(tok.text, 64); err != nil {
+			return nil, &FilterExpressionError{Pos: tok.pos, Msg: fmt.Sprintf("invalid number literal %q", tok.text)}
+		}
+		return tok.text, nil
+	case tokLParen:
+		p.advance()
+		var list []string
+		for {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, fmt.Sprintf("%v", v))
+			if p.current().kind == tokComma {
+				p.advance()
+				continue
+			}
+			break
+		}
+		if p.current().kind != tokRParen {
+			return nil, &FilterExpressionError{Pos: p.current().pos, Msg: "expected closing ')' in list literal"}
+		}
+		p.advance()
+		return list, nil
+	default:
+		return nil, &FilterExpressionError{Pos: tok.pos, Msg: fmt.Sprintf("expected a value, found %q", tok.text)}
+	}
+}
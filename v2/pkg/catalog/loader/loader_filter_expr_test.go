@@ -0,0 +1,78 @@
+package loader
+
+import "testing"
+
+func evalFilter(t *testing.T, expr string, info map[string]interface{}) bool {
+	t.Helper()
+
+	parsed, err := parseFilterExpression(expr)
+	if err != nil {
+		t.Fatalf("parseFilterExpression(%q) failed: %s", expr, err)
+	}
+	ok, err := parsed.Eval(info)
+	if err != nil {
+		t.Fatalf("Eval(%q) failed: %s", expr, err)
+	}
+	return ok
+}
+
+func TestParseFilterExpressionBareIdentifierLiteral(t *testing.T) {
+	info := map[string]interface{}{"severity": "critical"}
+
+	// A bare, unquoted identifier must be accepted as a value literal, same
+	// as a quoted string.
+	if !evalFilter(t, `severity == critical`, info) {
+		t.Errorf("expected severity == critical to match for severity=critical")
+	}
+	if evalFilter(t, `severity == "high"`, info) {
+		t.Errorf("expected severity == \"high\" not to match for severity=critical")
+	}
+}
+
+func TestParseFilterExpressionOperators(t *testing.T) {
+	info := map[string]interface{}{
+		"severity": "high",
+		"tags":     "cve,rce,oast",
+		"author":   "pdteam",
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`severity == high`, true},
+		{`severity != high`, false},
+		{`severity == low`, false},
+		{`severity in (low, high)`, true},
+		{`tag in (low, medium)`, false},
+		{`tag contains "rce"`, true},
+		{`tag contains "xxe"`, false},
+		{`author matches "pd*"`, true},
+		{`author matches "xy*"`, false},
+		{`severity == high and tag contains rce`, true},
+		{`severity == low and tag contains rce`, false},
+		{`severity == low or tag contains rce`, true},
+		{`not severity == low`, true},
+		{`not (severity == high and tag contains rce)`, false},
+	}
+	for _, tt := range tests {
+		if got := evalFilter(t, tt.expr, info); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseFilterExpressionSyntaxErrors(t *testing.T) {
+	tests := []string{
+		`severity ==`,
+		`severity == high and`,
+		`(severity == high`,
+		`severity ?? high`,
+		`severity == "unterminated`,
+	}
+	for _, expr := range tests {
+		if _, err := parseFilterExpression(expr); err == nil {
+			t.Errorf("parseFilterExpression(%q): expected an error, got none", expr)
+		}
+	}
+}
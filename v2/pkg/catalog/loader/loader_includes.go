@@ -0,0 +1,158 @@
+package loader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// maxTemplateIncludeDepth bounds how many extends/includes hops are
+// followed before resolveTemplateDocument gives up, guarding against deep
+// (but non-cyclic) chains in addition to the cycle detection below.
+const maxTemplateIncludeDepth = 10
+
+// resolveTemplateBytes reads templatePath and, if it declares `extends` or
+// `includes`, resolves and YAML-merges those base documents into it before
+// re-marshaling to the bytes that get decoded into *templates.Template.
+// Every template is decoded once into a generic map here to look for those
+// keys. For templates that declare neither, the raw bytes read from disk
+// are returned unchanged, with no merge pass and no re-marshal.
+func (s *Store) resolveTemplateBytes(templatePath string) ([]byte, error) {
+	data, err := ioutil.ReadFile(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	_, hasExtends := doc["extends"]
+	_, hasIncludes := doc["includes"]
+	if !hasExtends && !hasIncludes {
+		return data, nil
+	}
+
+	merged, err := s.resolveTemplateDocument(templatePath, doc, 0, map[string]struct{}{})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(merged)
+}
+
+// resolveTemplateDocument merges doc's `extends` base and `includes`
+// snippets into it, recursively. doc is the already-decoded document for
+// templatePath; base/include documents referenced from it are read and
+// decoded as they're encountered.
+func (s *Store) resolveTemplateDocument(templatePath string, doc map[string]interface{}, depth int, visiting map[string]struct{}) (map[string]interface{}, error) {
+	if depth > maxTemplateIncludeDepth {
+		return nil, fmt.Errorf("%s: max template include depth (%d) exceeded", templatePath, maxTemplateIncludeDepth)
+	}
+
+	absPath, err := filepath.Abs(templatePath)
+	if err != nil {
+		absPath = templatePath
+	}
+	if _, ok := visiting[absPath]; ok {
+		return nil, fmt.Errorf("%s: cyclic extends/includes detected", templatePath)
+	}
+	visiting[absPath] = struct{}{}
+	defer delete(visiting, absPath)
+
+	override, _ := doc["override"].(bool)
+	delete(doc, "override")
+
+	merged := map[string]interface{}{}
+	if extends, ok := doc["extends"].(string); ok && extends != "" {
+		baseDoc, err := s.readAndResolveTemplateDocument(s.resolveIncludePath(extends), depth+1, visiting)
+		if err != nil {
+			return nil, fmt.Errorf("%s: extends %s: %w", templatePath, extends, err)
+		}
+		merged = baseDoc
+	}
+	delete(doc, "extends")
+
+	if rawIncludes, ok := doc["includes"].([]interface{}); ok {
+		for _, rawInclude := range rawIncludes {
+			includePath, ok := rawInclude.(string)
+			if !ok {
+				continue
+			}
+			includeDoc, err := s.readAndResolveTemplateDocument(s.resolveIncludePath(includePath), depth+1, visiting)
+			if err != nil {
+				return nil, fmt.Errorf("%s: includes %s: %w", templatePath, includePath, err)
+			}
+			merged = mergeTemplateDocuments(merged, includeDoc, override)
+		}
+	}
+	delete(doc, "includes")
+
+	merged = mergeTemplateDocuments(merged, doc, override)
+	return merged, nil
+}
+
+// readAndResolveTemplateDocument reads and decodes templatePath, then
+// resolves its own extends/includes before returning the merged document.
+func (s *Store) readAndResolveTemplateDocument(templatePath string, depth int, visiting map[string]struct{}) (map[string]interface{}, error) {
+	doc, err := readTemplateDocument(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolveTemplateDocument(templatePath, doc, depth, visiting)
+}
+
+// resolveIncludePath resolves an extends/includes reference relative to the
+// configured TemplatesDirectory, per the request's contract.
+func (s *Store) resolveIncludePath(ref string) string {
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(s.config.TemplatesDirectory, ref)
+}
+
+// readTemplateDocument reads and YAML-decodes a template file into a
+// generic map, preserving fields (matchers, requests, workflows, ...) that
+// loader does not itself know about.
+func readTemplateDocument(templatePath string) (map[string]interface{}, error) {
+	f, err := os.Open(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// mergeTemplateDocuments merges child into base: scalar and map child keys
+// win outright, while list-typed keys (matchers, requests, workflows, ...)
+// are appended to the base's list instead of replacing it, unless override
+// is set.
+func mergeTemplateDocuments(base, child map[string]interface{}, override bool) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(child))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, childValue := range child {
+		baseValue, hasBase := merged[k]
+		childList, childIsList := childValue.([]interface{})
+		baseList, baseIsList := baseValue.([]interface{})
+		if !override && hasBase && childIsList && baseIsList {
+			merged[k] = append(append([]interface{}{}, baseList...), childList...)
+			continue
+		}
+		merged[k] = childValue
+	}
+	return merged
+}
@@ -0,0 +1,195 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func newIncludesStore(t *testing.T, dir string) *Store {
+	t.Helper()
+
+	store, err := New(&Config{TemplatesDirectory: dir})
+	if err != nil {
+		t.Fatalf("could not create store: %s", err)
+	}
+	return store
+}
+
+func writeTemplate(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("could not write %s: %s", name, err)
+	}
+	return path
+}
+
+func decodeDoc(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("could not decode document: %s", err)
+	}
+	return doc
+}
+
+func TestResolveTemplateBytesNoExtendsOrIncludes(t *testing.T) {
+	dir := t.TempDir()
+	store := newIncludesStore(t, dir)
+
+	const raw = "id: plain\ninfo:\n  name: Plain\n"
+	path := writeTemplate(t, dir, "plain.yaml", raw)
+
+	data, err := store.resolveTemplateBytes(path)
+	if err != nil {
+		t.Fatalf("resolveTemplateBytes failed: %s", err)
+	}
+	if string(data) != raw {
+		t.Errorf("resolveTemplateBytes changed bytes for a template with no extends/includes:\ngot:  %q\nwant: %q", data, raw)
+	}
+}
+
+func TestResolveTemplateBytesExtendsAppendsLists(t *testing.T) {
+	dir := t.TempDir()
+	store := newIncludesStore(t, dir)
+
+	writeTemplate(t, dir, "base.yaml", `
+id: base
+info:
+  name: Base
+  severity: info
+requests:
+  - method: GET
+`)
+	child := writeTemplate(t, dir, "child.yaml", `
+extends: base.yaml
+id: child
+info:
+  name: Child
+requests:
+  - method: POST
+`)
+
+	merged, err := store.resolveTemplateBytes(child)
+	if err != nil {
+		t.Fatalf("resolveTemplateBytes failed: %s", err)
+	}
+	doc := decodeDoc(t, merged)
+
+	if doc["id"] != "child" {
+		t.Errorf("id = %v, want child (child scalar should win over base)", doc["id"])
+	}
+	requests, ok := doc["requests"].([]interface{})
+	if !ok || len(requests) != 2 {
+		t.Fatalf("requests = %v, want a 2-element list (base + child appended)", doc["requests"])
+	}
+}
+
+func TestResolveTemplateBytesOverrideReplacesLists(t *testing.T) {
+	dir := t.TempDir()
+	store := newIncludesStore(t, dir)
+
+	writeTemplate(t, dir, "base.yaml", `
+id: base
+requests:
+  - method: GET
+`)
+	child := writeTemplate(t, dir, "child.yaml", `
+extends: base.yaml
+override: true
+id: child
+requests:
+  - method: POST
+`)
+
+	merged, err := store.resolveTemplateBytes(child)
+	if err != nil {
+		t.Fatalf("resolveTemplateBytes failed: %s", err)
+	}
+	doc := decodeDoc(t, merged)
+
+	requests, ok := doc["requests"].([]interface{})
+	if !ok || len(requests) != 1 {
+		t.Fatalf("requests = %v, want a single-element list (override replaces base)", doc["requests"])
+	}
+	if _, ok := doc["override"]; ok {
+		t.Errorf("override key leaked into merged document: %v", doc)
+	}
+}
+
+func TestResolveTemplateBytesIncludesMerge(t *testing.T) {
+	dir := t.TempDir()
+	store := newIncludesStore(t, dir)
+
+	writeTemplate(t, dir, "snippet.yaml", `
+requests:
+  - method: GET
+`)
+	child := writeTemplate(t, dir, "child.yaml", `
+id: child
+includes:
+  - snippet.yaml
+requests:
+  - method: POST
+`)
+
+	merged, err := store.resolveTemplateBytes(child)
+	if err != nil {
+		t.Fatalf("resolveTemplateBytes failed: %s", err)
+	}
+	doc := decodeDoc(t, merged)
+
+	requests, ok := doc["requests"].([]interface{})
+	if !ok || len(requests) != 2 {
+		t.Fatalf("requests = %v, want a 2-element list (snippet + child appended)", doc["requests"])
+	}
+}
+
+func TestResolveTemplateBytesDetectsCycle(t *testing.T) {
+	dir := t.TempDir()
+	store := newIncludesStore(t, dir)
+
+	writeTemplate(t, dir, "a.yaml", `
+extends: b.yaml
+id: a
+`)
+	b := writeTemplate(t, dir, "b.yaml", `
+extends: a.yaml
+id: b
+`)
+
+	if _, err := store.resolveTemplateBytes(b); err == nil {
+		t.Fatal("resolveTemplateBytes: expected a cyclic extends error, got none")
+	}
+}
+
+func TestResolveTemplateBytesDetectsMaxDepthExceeded(t *testing.T) {
+	dir := t.TempDir()
+	store := newIncludesStore(t, dir)
+
+	var last string
+	for i := 0; i <= maxTemplateIncludeDepth+1; i++ {
+		name := filepath.Join(dir, templateChainName(i))
+		content := "id: chain\n"
+		if i > 0 {
+			content = "extends: " + templateChainName(i-1) + "\nid: chain\n"
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("could not write %s: %s", name, err)
+		}
+		last = name
+	}
+
+	if _, err := store.resolveTemplateBytes(last); err == nil {
+		t.Fatal("resolveTemplateBytes: expected a max include depth error, got none")
+	}
+}
+
+func templateChainName(i int) string {
+	return "chain" + string(rune('a'+i)) + ".yaml"
+}
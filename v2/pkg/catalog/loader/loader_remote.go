@@ -0,0 +1,400 @@
+package loader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// RemoteSourceType describes the protocol nuclei should use to fetch a
+// RemoteSource.
+type RemoteSourceType string
+
+const (
+	// RemoteSourceGit fetches templates from a git repository.
+	RemoteSourceGit RemoteSourceType = "git"
+	// RemoteSourceHTTP fetches templates from an HTTP(S) tarball or zip URL.
+	RemoteSourceHTTP RemoteSourceType = "http"
+)
+
+// RemoteSource describes an external location that template files can be
+// fetched from, in addition to the local TemplatesDirectory.
+type RemoteSource struct {
+	// Type is the kind of remote source, git or http. If empty, it is
+	// inferred: a non-empty Ref implies git, otherwise http.
+	Type RemoteSourceType
+
+	// URL is the git remote URL or the HTTP(S) tarball/zip URL.
+	URL string
+	// Ref is the git branch or tag to check out. Only used for git
+	// sources; ignored for http sources. A fresh clone passes Ref to
+	// `git clone --branch`, which does not accept a commit hash.
+	Ref string
+	// Subpath restricts the fetched source to a subdirectory, relative to
+	// the repository root or archive root.
+	Subpath string
+	// Checksum, if set, is an expected value the fetched source must match
+	// before it is trusted: for http sources, a hex-encoded sha256 sum of
+	// the downloaded archive; for git sources, the resolved commit hash
+	// (e.g. as printed by `git rev-parse`) that Ref must check out to.
+	Checksum string
+
+	// Username and Token are optional credentials used for authenticated
+	// git/http fetches.
+	Username string
+	Token    string
+}
+
+// cacheDir returns the directory fetched remote sources are cached under,
+// namespaced by TemplatesDirectory so multiple configurations don't collide.
+func (c *Config) cacheDir() string {
+	return filepath.Join(c.TemplatesDirectory, ".remote")
+}
+
+// loadRemoteSources fetches (or reuses a previously fetched copy of) every
+// configured RemoteSource and returns the local paths that should be merged
+// into finalTemplates. Sources are only refreshed from the network when
+// config.UpdateRemote is set or no cached copy exists yet.
+func loadRemoteSources(config *Config) ([]string, error) {
+	if len(config.RemoteSources) == 0 {
+		return nil, nil
+	}
+	cacheDir := config.cacheDir()
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create remote template cache: %w", err)
+	}
+
+	var paths []string
+	for _, source := range config.RemoteSources {
+		path, err := fetchRemoteSource(cacheDir, source, config.UpdateRemote)
+		if err != nil {
+			gologger.Warning().Msgf("Could not fetch remote template source %s: %s\n", source.URL, err)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// fetchRemoteSource resolves a single RemoteSource into a local directory,
+// fetching it (or refreshing an existing checkout) as needed.
+func fetchRemoteSource(cacheDir string, source RemoteSource, updateRemote bool) (string, error) {
+	dest := filepath.Join(cacheDir, sourceHash(source))
+
+	sourceType := source.Type
+	if sourceType == "" {
+		if source.Ref != "" {
+			sourceType = RemoteSourceGit
+		} else {
+			sourceType = RemoteSourceHTTP
+		}
+	}
+
+	_, err := os.Stat(dest)
+	exists := err == nil
+	if exists && !updateRemote {
+		return filepath.Join(dest, source.Subpath), nil
+	}
+
+	switch sourceType {
+	case RemoteSourceGit:
+		if err := fetchGitSource(dest, source, exists); err != nil {
+			return "", err
+		}
+	case RemoteSourceHTTP:
+		if err := fetchHTTPSource(dest, source); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unknown remote source type: %s", sourceType)
+	}
+	return filepath.Join(dest, source.Subpath), nil
+}
+
+// fetchGitSource clones source into dest, or fetches and checks out Ref if
+// dest is already a clone from a previous run. When source.Checksum is set,
+// the clone/fetch instead happens into a scratch directory that is only
+// renamed into dest once the resolved commit has been verified against it,
+// so a checksum failure never leaves a bad (or half-updated) checkout
+// sitting in dest for a later, non-verifying call to pick up as trusted.
+func fetchGitSource(dest string, source RemoteSource, exists bool) error {
+	if source.Checksum == "" {
+		return fetchGitSourceInPlace(dest, source, exists)
+	}
+
+	scratch := dest + ".verify"
+	if err := os.RemoveAll(scratch); err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+
+	if err := fetchGitSourceInPlace(scratch, source, false); err != nil {
+		return err
+	}
+
+	commit, err := resolveGitCommit(scratch)
+	if err != nil {
+		return fmt.Errorf("could not resolve HEAD commit: %w", err)
+	}
+	if !strings.EqualFold(commit, source.Checksum) {
+		return fmt.Errorf("checksum mismatch: expected commit %s, got %s", source.Checksum, commit)
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	return os.Rename(scratch, dest)
+}
+
+// fetchGitSourceInPlace clones source directly into dest, or fetches and
+// checks out Ref if dest is already a clone from a previous run.
+func fetchGitSourceInPlace(dest string, source RemoteSource, exists bool) error {
+	if !exists {
+		args := []string{"clone", "--depth", "1"}
+		if source.Ref != "" {
+			args = append(args, "--branch", source.Ref)
+		}
+		args = append(args, source.URL, dest)
+		if err := runGitCommand(source, "", args...); err != nil {
+			return fmt.Errorf("git clone failed: %w", err)
+		}
+		return nil
+	}
+
+	fetchArgs := []string{"fetch", "--depth", "1", "origin"}
+	if source.Ref != "" {
+		fetchArgs = append(fetchArgs, source.Ref)
+	}
+	if err := runGitCommand(source, dest, fetchArgs...); err != nil {
+		return fmt.Errorf("git fetch failed: %w", err)
+	}
+	checkoutRef := "FETCH_HEAD"
+	if source.Ref == "" {
+		checkoutRef = "origin/HEAD"
+	}
+	if err := runGitCommand(source, dest, "checkout", checkoutRef); err != nil {
+		return fmt.Errorf("git checkout failed: %w", err)
+	}
+	return nil
+}
+
+// resolveGitCommit returns the commit hash dest's HEAD is checked out to.
+func resolveGitCommit(dest string) (string, error) {
+	out, err := exec.Command("git", "-C", dest, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runGitCommand runs git with the given args against dir (the repo's
+// working copy, or "" for a fresh clone), authenticating via an
+// http.extraHeader passed through GIT_CONFIG_* environment variables rather
+// than an argv-visible URL or command-line flag. On failure, the error
+// omits git's raw stderr/stdout whenever credentials are in play, since that
+// output routinely echoes the attempted URL (and, for some remotes, the
+// credentials themselves) back verbatim.
+func runGitCommand(source RemoteSource, dir string, args ...string) error {
+	fullArgs := args
+	if dir != "" {
+		fullArgs = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.Command("git", fullArgs...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	authenticated := source.Username != "" || source.Token != ""
+	if authenticated && (strings.HasPrefix(source.URL, "http://") || strings.HasPrefix(source.URL, "https://")) {
+		header := "Authorization: Basic " + basicAuthHeader(source.Username, source.Token)
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0="+header,
+		)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if authenticated {
+		return errors.New("command failed (output withheld because the remote source is authenticated)")
+	}
+	return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+}
+
+// basicAuthHeader base64-encodes "username:token" for an HTTP Basic
+// Authorization header.
+func basicAuthHeader(username, token string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + token))
+}
+
+// fetchHTTPSource downloads a tarball or zip archive and extracts it into
+// dest, verifying its checksum first if one was configured.
+func fetchHTTPSource(dest string, source RemoteSource) error {
+	req, err := http.NewRequest(http.MethodGet, source.URL, nil)
+	if err != nil {
+		return err
+	}
+	if source.Username != "" || source.Token != "" {
+		req.SetBasicAuth(source.Username, source.Token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d fetching %s", resp.StatusCode, source.URL)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if source.Checksum != "" {
+		if err := verifyChecksum(data, source.Checksum); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(source.URL, ".zip") {
+		return extractZip(data, dest)
+	}
+	return extractTarGz(data, dest)
+}
+
+// verifyChecksum returns an error if the sha256 sum of data does not match
+// the expected hex-encoded checksum.
+func verifyChecksum(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	got := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(got, expected) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, got)
+	}
+	return nil
+}
+
+// extractTarGz extracts a gzip-compressed tarball into dest.
+func extractTarGz(data []byte, dest string) error {
+	gzr, err := gzip.NewReader(strings.NewReader(string(data)))
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(dest, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			f.Close()
+		}
+	}
+}
+
+// extractZip extracts a zip archive into dest.
+func extractZip(data []byte, dest string) error {
+	zr, err := zip.NewReader(strings.NewReader(string(data)), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, file := range zr.File {
+		target, err := safeJoin(dest, file.Name)
+		if err != nil {
+			return err
+		}
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode())
+		if err != nil {
+			src.Close()
+			return err
+		}
+		_, copyErr := io.Copy(dst, src)
+		src.Close()
+		dst.Close()
+		if copyErr != nil {
+			return copyErr
+		}
+	}
+	return nil
+}
+
+// safeJoin joins dest with an archive entry name, refusing to return a path
+// that escapes dest (an absolute entry, or one containing a "../" that
+// climbs past dest), as a malicious or compromised archive could otherwise
+// write files anywhere on disk (Zip-Slip).
+func safeJoin(dest, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("archive entry has an absolute path: %s", name)
+	}
+	target := filepath.Join(dest, name)
+	destWithSep := filepath.Clean(dest) + string(os.PathSeparator)
+	if target != filepath.Clean(dest) && !strings.HasPrefix(target, destWithSep) {
+		return "", fmt.Errorf("archive entry escapes destination directory: %s", name)
+	}
+	return target, nil
+}
+
+// sourceHash derives a stable cache directory name for a RemoteSource.
+func sourceHash(source RemoteSource) string {
+	sum := sha256.Sum256([]byte(source.URL + "|" + source.Ref + "|" + source.Subpath))
+	return hex.EncodeToString(sum[:])[:16]
+}
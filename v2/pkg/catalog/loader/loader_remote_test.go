@@ -0,0 +1,97 @@
+package loader
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoinRejectsEscapingEntries(t *testing.T) {
+	dest := filepath.Join(os.TempDir(), "loader-safejoin-dest")
+
+	tests := []string{
+		"/etc/passwd",
+		"../../etc/passwd",
+		"nested/../../escape.yaml",
+		"..",
+	}
+	for _, name := range tests {
+		if _, err := safeJoin(dest, name); err == nil {
+			t.Errorf("safeJoin(%q, %q): expected an error, got none", dest, name)
+		}
+	}
+}
+
+func TestSafeJoinAllowsWellFormedEntries(t *testing.T) {
+	dest := filepath.Join(os.TempDir(), "loader-safejoin-dest")
+
+	tests := map[string]string{
+		"templates/cve.yaml": filepath.Join(dest, "templates", "cve.yaml"),
+		"cve.yaml":           filepath.Join(dest, "cve.yaml"),
+	}
+	for name, want := range tests {
+		got, err := safeJoin(dest, name)
+		if err != nil {
+			t.Fatalf("safeJoin(%q, %q) failed: %s", dest, name, err)
+		}
+		if got != want {
+			t.Errorf("safeJoin(%q, %q) = %q, want %q", dest, name, got, want)
+		}
+	}
+}
+
+func TestExtractZipRejectsZipSlip(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "dest")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("../evil.yaml")
+	if err != nil {
+		t.Fatalf("could not create zip entry: %s", err)
+	}
+	if _, err := w.Write([]byte("id: evil\n")); err != nil {
+		t.Fatalf("could not write zip entry: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("could not close zip writer: %s", err)
+	}
+
+	if err := extractZip(buf.Bytes(), dest); err == nil {
+		t.Fatal("extractZip: expected an error for a zip-slip entry, got none")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("extractZip: zip-slip entry escaped dest onto disk (err=%v)", err)
+	}
+}
+
+func TestExtractZipWellFormedArchive(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "dest")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("templates/cve.yaml")
+	if err != nil {
+		t.Fatalf("could not create zip entry: %s", err)
+	}
+	if _, err := w.Write([]byte("id: cve\n")); err != nil {
+		t.Fatalf("could not write zip entry: %s", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("could not close zip writer: %s", err)
+	}
+
+	if err := extractZip(buf.Bytes(), dest); err != nil {
+		t.Fatalf("extractZip failed on a well-formed archive: %s", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "templates", "cve.yaml"))
+	if err != nil {
+		t.Fatalf("extracted file missing: %s", err)
+	}
+	if string(data) != "id: cve\n" {
+		t.Errorf("extracted file content = %q, want %q", data, "id: cve\n")
+	}
+}
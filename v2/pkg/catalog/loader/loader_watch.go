@@ -0,0 +1,191 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/projectdiscovery/nuclei/v2/pkg/templates"
+)
+
+// StoreEventOp describes the kind of change a StoreEvent reports.
+type StoreEventOp string
+
+const (
+	// StoreEventLoad is emitted when a template was (re)loaded successfully,
+	// or failed to load (see StoreEvent.Err).
+	StoreEventLoad StoreEventOp = "load"
+	// StoreEventRemove is emitted when a previously loaded template was
+	// deleted or renamed away and dropped from the store.
+	StoreEventRemove StoreEventOp = "remove"
+)
+
+// StoreEvent is emitted on the channel returned by Store.Watch whenever a
+// watched template file changes.
+type StoreEvent struct {
+	Path     string
+	Op       StoreEventOp
+	Template *templates.Template
+	Err      error
+}
+
+// Watch starts watching every directory contributing to the store's
+// finalTemplates for changes, using fsnotify, and returns a channel of
+// StoreEvent describing them. Changed files are reloaded and swapped into
+// the store under lock so concurrent Templates()/Workflows() callers always
+// see a consistent set. Errors reported by the underlying watcher are
+// delivered as a StoreEvent with Err set, and watching continues. The
+// returned channel is closed once ctx is done or the underlying watcher's
+// channels are closed. Callers that never call Watch observe no change in
+// behavior.
+func (s *Store) Watch(ctx context.Context) (<-chan StoreEvent, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range s.watchedDirectories() {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	events := make(chan StoreEvent)
+	go s.watchLoop(ctx, watcher, events)
+	return events, nil
+}
+
+// watchedDirectories walks finalTemplates and returns the unique set of
+// directories to register with fsnotify. fsnotify watches are not
+// recursive, so every nested directory needs to be added individually.
+func (s *Store) watchedDirectories() []string {
+	seen := make(map[string]struct{})
+	var dirs []string
+	for _, path := range s.finalTemplates {
+		_ = filepath.Walk(path, func(walked string, info os.FileInfo, err error) error {
+			if err != nil || info == nil || !info.IsDir() {
+				return nil
+			}
+			if _, ok := seen[walked]; !ok {
+				seen[walked] = struct{}{}
+				dirs = append(dirs, walked)
+			}
+			return nil
+		})
+	}
+	return dirs
+}
+
+// watchLoop is the fsnotify event pump driving Watch. It owns watcher and
+// events and closes both before returning.
+func (s *Store) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, events chan StoreEvent) {
+	defer watcher.Close()
+	defer close(events)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			if !s.emit(ctx, events, StoreEvent{Op: StoreEventLoad, Err: err}) {
+				return
+			}
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !isTemplateFile(event.Name) {
+				continue
+			}
+			if !s.handleWatchEvent(ctx, event, events) {
+				return
+			}
+		}
+	}
+}
+
+// emit delivers ev on events, but gives up and reports false as soon as ctx
+// is done instead of blocking forever on a consumer that stopped draining
+// the channel.
+func (s *Store) emit(ctx context.Context, events chan<- StoreEvent, ev StoreEvent) bool {
+	select {
+	case events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// isTemplateFile reports whether path looks like a nuclei template/workflow
+// YAML file worth reacting to.
+func isTemplateFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// handleWatchEvent reacts to a single fsnotify event, reloading or removing
+// the affected template and publishing the outcome. It returns false if ctx
+// was done before the outcome could be delivered, signaling the caller to
+// stop watching.
+func (s *Store) handleWatchEvent(ctx context.Context, event fsnotify.Event, events chan<- StoreEvent) bool {
+	switch {
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		s.removeTemplate(event.Name)
+		return s.emit(ctx, events, StoreEvent{Path: event.Name, Op: StoreEventRemove})
+	case event.Op&(fsnotify.Write|fsnotify.Create) != 0:
+		return s.reloadTemplate(ctx, event.Name, events)
+	}
+	return true
+}
+
+// removeTemplate drops path from both the templates and workflows maps.
+func (s *Store) removeTemplate(path string) {
+	s.mu.Lock()
+	delete(s.templates, path)
+	delete(s.workflows, path)
+	s.mu.Unlock()
+}
+
+// reloadTemplate re-runs loadTemplateParseMetadata and templates.ParseFromDecoded
+// for path and atomically swaps the result into the store, emitting a
+// StoreEvent with either the freshly parsed template or the error hit along
+// the way. Any failure to reload (a transient read/YAML error, or the edit
+// taking the template out of the active filter) drops the stale, previously
+// loaded entry for path rather than leaving it live in the store. It returns
+// false if ctx was done before the outcome could be delivered.
+func (s *Store) reloadTemplate(ctx context.Context, path string, events chan<- StoreEvent) bool {
+	decoded, loaded, err := s.loadTemplateParseMetadata(path, false)
+	isWorkflow := false
+	if err == nil && !loaded {
+		decoded, loaded, err = s.loadTemplateParseMetadata(path, true)
+		isWorkflow = true
+	}
+	if err != nil {
+		s.removeTemplate(path)
+		return s.emit(ctx, events, StoreEvent{Path: path, Op: StoreEventLoad, Err: err})
+	}
+	if !loaded {
+		s.removeTemplate(path)
+		return true
+	}
+
+	parsed, err := templates.ParseFromDecoded(decoded, path, s.config.ExecutorOptions)
+	if err != nil {
+		s.removeTemplate(path)
+		return s.emit(ctx, events, StoreEvent{Path: path, Op: StoreEventLoad, Err: err})
+	}
+
+	s.mu.Lock()
+	if isWorkflow {
+		s.workflows[path] = parsed
+	} else {
+		s.templates[path] = parsed
+	}
+	s.mu.Unlock()
+
+	return s.emit(ctx, events, StoreEvent{Path: path, Op: StoreEventLoad, Template: parsed})
+}
@@ -0,0 +1,26 @@
+package templates
+
+import (
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols"
+)
+
+// ParseFromDecoded compiles an already YAML-decoded Template into an
+// executable one, without re-reading or re-decoding filePath from disk.
+// Parse performs the read+decode step and then delegates to this for the
+// actual compilation, so callers that already hold a decoded document
+// (loader.Store decodes once, for metadata filtering) can call this
+// directly and skip Parse's redundant read+decode entirely.
+func ParseFromDecoded(t *Template, filePath string, options protocols.ExecuterOptions) (*Template, error) {
+	return compileTemplate(t, filePath, options)
+}
+
+// Parse reads filePath, decodes it into a Template and compiles it via
+// ParseFromDecoded, so there is a single compilation code path regardless
+// of whether the caller already had a decoded document in hand.
+func Parse(filePath string, options protocols.ExecuterOptions) (*Template, error) {
+	template, err := decodeTemplate(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFromDecoded(template, filePath, options)
+}
@@ -0,0 +1,52 @@
+package templates
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/projectdiscovery/nuclei/v2/pkg/protocols"
+	"gopkg.in/yaml.v2"
+)
+
+// Template is a parsed nuclei template or workflow.
+type Template struct {
+	// Info contains metadata about the template, such as name, author,
+	// severity and tags.
+	Info map[string]interface{} `yaml:"info"`
+	// Workflows is the list of templates a workflow executes as its steps.
+	// A non-empty Workflows marks this Template as a workflow rather than a
+	// regular template.
+	Workflows []*WorkflowTemplate `yaml:"workflows,omitempty"`
+
+	// path is the template file this Template was parsed from.
+	path string
+}
+
+// WorkflowTemplate is a single step of a workflow template.
+type WorkflowTemplate struct {
+	Template string `yaml:"template"`
+}
+
+// decodeTemplate reads and YAML-decodes filePath into a Template.
+func decodeTemplate(filePath string) (*Template, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	template := &Template{}
+	if err := yaml.Unmarshal(data, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// compileTemplate finishes turning a decoded Template into an executable
+// one. Parse and ParseFromDecoded both funnel through here so there is a
+// single compilation code path regardless of how the Template was decoded.
+func compileTemplate(t *Template, filePath string, options protocols.ExecuterOptions) (*Template, error) {
+	if t == nil {
+		return nil, fmt.Errorf("%s: template is nil", filePath)
+	}
+	t.path = filePath
+	return t, nil
+}